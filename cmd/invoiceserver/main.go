@@ -0,0 +1,51 @@
+// Command invoiceserver serves invoiceservice.Server over gRPC: the
+// structured, source-PDF-free path for creating, sealing and rendering
+// invoices that the CLI tool in the repo root doesn't cover.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/panda/pdfeditor/invoice"
+	"github.com/panda/pdfeditor/invoice/invoiceservice"
+)
+
+func main() {
+	addr := flag.String("addr", ":50051", "address to listen on")
+	dbPath := flag.String("db", "invoices.db", "path to the SQLite invoice store")
+	flag.Parse()
+
+	store, err := invoice.NewSQLiteStore(*dbPath)
+	if err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	srv, err := invoiceservice.NewServer(store)
+	if err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		logrus.Error(fmt.Errorf("couldn't listen on %s: %v", *addr, err))
+		os.Exit(1)
+	}
+
+	s := grpc.NewServer()
+	invoiceservice.RegisterInvoiceServer(s, srv)
+
+	logrus.Infof("invoiceservice listening on %s", *addr)
+	if err := s.Serve(lis); err != nil {
+		logrus.Error(err)
+		os.Exit(1)
+	}
+}
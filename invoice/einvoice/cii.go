@@ -0,0 +1,207 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/panda/pdfeditor/invoice"
+)
+
+// ciiInvoice is the root of a UN/CEFACT Cross Industry Invoice document,
+// the format German XRechnung (and ZUGFeRD's embedded XML) both use.
+// As in ubl.go, namespace prefixes (rsm:/ram:/udt:) are written as
+// literal tag text rather than through xml.Name, matching the prefixes
+// declared on the root element.
+type ciiInvoice struct {
+	XMLName  xml.Name `xml:"rsm:CrossIndustryInvoice"`
+	XmlnsRsm string   `xml:"xmlns:rsm,attr"`
+	XmlnsRam string   `xml:"xmlns:ram,attr"`
+	XmlnsUdt string   `xml:"xmlns:udt,attr"`
+
+	ExchangedDocument           ciiExchangedDocument           `xml:"rsm:ExchangedDocument"`
+	SupplyChainTradeTransaction ciiSupplyChainTradeTransaction `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+type ciiExchangedDocument struct {
+	ID            string          `xml:"ram:ID"`       // BT-1
+	TypeCode      string          `xml:"ram:TypeCode"` // 380 = commercial invoice
+	IssueDateTime ciiDateTimeWrap `xml:"ram:IssueDateTime"`
+}
+
+type ciiDateTimeWrap struct {
+	DateTimeString ciiDateTimeString `xml:"udt:DateTimeString"`
+}
+
+// ciiDateTimeString is a CII date in its "102" format: YYYYMMDD.
+type ciiDateTimeString struct {
+	Format string `xml:"format,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type ciiSupplyChainTradeTransaction struct {
+	IncludedSupplyChainTradeLineItem []ciiLineItem            `xml:"ram:IncludedSupplyChainTradeLineItem"` // BG-25
+	ApplicableHeaderTradeAgreement   ciiHeaderTradeAgreement  `xml:"ram:ApplicableHeaderTradeAgreement"`
+	ApplicableHeaderTradeSettlement  ciiHeaderTradeSettlement `xml:"ram:ApplicableHeaderTradeSettlement"`
+}
+
+type ciiHeaderTradeAgreement struct {
+	SellerTradeParty ciiTradeParty `xml:"ram:SellerTradeParty"`
+	BuyerTradeParty  ciiTradeParty `xml:"ram:BuyerTradeParty"`
+}
+
+type ciiTradeParty struct {
+	Name                     string             `xml:"ram:Name"`
+	SpecifiedTaxRegistration ciiTaxRegistration `xml:"ram:SpecifiedTaxRegistration"`
+}
+
+// ciiTaxRegistration carries the party's VAT ID: BT-31 on the seller,
+// BT-48 on the buyer. schemeID="VA" marks it as a VAT registration
+// number, per the UNTDID 1153 code list CII uses.
+type ciiTaxRegistration struct {
+	ID ciiSchemedID `xml:"ram:ID"`
+}
+
+type ciiSchemedID struct {
+	SchemeID string `xml:"schemeID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ciiHeaderTradeSettlement struct {
+	InvoiceCurrencyCode                             string               `xml:"ram:InvoiceCurrencyCode"`
+	SpecifiedTradeSettlementHeaderMonetarySummation ciiMonetarySummation `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+}
+
+type ciiMonetarySummation struct {
+	LineTotalAmount  string    `xml:"ram:LineTotalAmount"`  // BT-106
+	TaxTotalAmount   ciiAmount `xml:"ram:TaxTotalAmount"`   // BT-110
+	GrandTotalAmount string    `xml:"ram:GrandTotalAmount"` // BT-112
+	DuePayableAmount string    `xml:"ram:DuePayableAmount"`
+}
+
+type ciiAmount struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type ciiLineItem struct {
+	AssociatedDocumentLineDocument ciiLineDocument        `xml:"ram:AssociatedDocumentLineDocument"`
+	SpecifiedTradeProduct          ciiTradeProduct        `xml:"ram:SpecifiedTradeProduct"`
+	SpecifiedLineTradeAgreement    ciiLineTradeAgreement  `xml:"ram:SpecifiedLineTradeAgreement"`
+	SpecifiedLineTradeDelivery     ciiLineTradeDelivery   `xml:"ram:SpecifiedLineTradeDelivery"`
+	SpecifiedLineTradeSettlement   ciiLineTradeSettlement `xml:"ram:SpecifiedLineTradeSettlement"`
+}
+
+type ciiLineDocument struct {
+	LineID string `xml:"ram:LineID"`
+}
+
+type ciiTradeProduct struct {
+	Name string `xml:"ram:Name"`
+}
+
+type ciiLineTradeAgreement struct {
+	NetPriceProductTradePrice ciiTradePrice `xml:"ram:NetPriceProductTradePrice"`
+}
+
+type ciiTradePrice struct {
+	ChargeAmount string `xml:"ram:ChargeAmount"`
+}
+
+type ciiLineTradeDelivery struct {
+	BilledQuantity ciiQuantity `xml:"ram:BilledQuantity"`
+}
+
+type ciiQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ciiLineTradeSettlement struct {
+	ApplicableTradeTax                            ciiLineTax               `xml:"ram:ApplicableTradeTax"`
+	SpecifiedTradeSettlementLineMonetarySummation ciiLineMonetarySummation `xml:"ram:SpecifiedTradeSettlementLineMonetarySummation"`
+}
+
+type ciiLineTax struct {
+	RateApplicablePercent string `xml:"ram:RateApplicablePercent"`
+}
+
+type ciiLineMonetarySummation struct {
+	LineTotalAmount string `xml:"ram:LineTotalAmount"`
+}
+
+// BuildXRechnung renders inv as a German XRechnung invoice: a UN/CEFACT
+// Cross Industry Invoice (CII) document, the same XML ZUGFeRD embeds
+// into its PDF. inv must already have gone through
+// invoice.PDFCreator.ComputeVAT — see the package doc.
+func BuildXRechnung(inv invoice.Invoice) ([]byte, error) {
+	var vatTotal, lineTotal float64
+	lines := make([]ciiLineItem, 0, len(inv.Items))
+	for i, item := range inv.Items {
+		net := item.Qty * item.UnitPrice
+		lineTotal += net
+		vatTotal += net * item.VATRate
+		lines = append(lines, ciiLineItem{
+			AssociatedDocumentLineDocument: ciiLineDocument{LineID: formatAmount(float64(i + 1))},
+			SpecifiedTradeProduct:          ciiTradeProduct{Name: item.Title},
+			SpecifiedLineTradeAgreement: ciiLineTradeAgreement{
+				NetPriceProductTradePrice: ciiTradePrice{ChargeAmount: formatAmount(item.UnitPrice)},
+			},
+			SpecifiedLineTradeDelivery: ciiLineTradeDelivery{
+				BilledQuantity: ciiQuantity{UnitCode: "C62", Value: formatAmount(item.Qty)},
+			},
+			SpecifiedLineTradeSettlement: ciiLineTradeSettlement{
+				ApplicableTradeTax: ciiLineTax{RateApplicablePercent: formatAmount(item.VATRate * 100)},
+				SpecifiedTradeSettlementLineMonetarySummation: ciiLineMonetarySummation{LineTotalAmount: formatAmount(net)},
+			},
+		})
+	}
+	vatTotal += inv.Shipping * inv.ShippingVATRate
+	taxExclusive := lineTotal + inv.Shipping - inv.Discount
+	taxInclusive := taxExclusive + vatTotal
+
+	tradeParty := func(p invoice.Party) ciiTradeParty {
+		return ciiTradeParty{
+			Name:                     p.Name,
+			SpecifiedTaxRegistration: ciiTaxRegistration{ID: ciiSchemedID{SchemeID: "VA", Value: p.VATID}},
+		}
+	}
+
+	doc := ciiInvoice{
+		XmlnsRsm: "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100",
+		XmlnsRam: "urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100",
+		XmlnsUdt: "urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100",
+		ExchangedDocument: ciiExchangedDocument{
+			ID:       inv.Number, // BT-1
+			TypeCode: "380",
+			IssueDateTime: ciiDateTimeWrap{
+				DateTimeString: ciiDateTimeString{Format: "102", Value: inv.Date.Format("20060102")}, // BT-2
+			},
+		},
+		SupplyChainTradeTransaction: ciiSupplyChainTradeTransaction{
+			IncludedSupplyChainTradeLineItem: lines,
+			ApplicableHeaderTradeAgreement: ciiHeaderTradeAgreement{
+				SellerTradeParty: tradeParty(inv.Seller), // BT-31 via SpecifiedTaxRegistration
+				BuyerTradeParty:  tradeParty(inv.Buyer),  // BT-48 via SpecifiedTaxRegistration
+			},
+			ApplicableHeaderTradeSettlement: ciiHeaderTradeSettlement{
+				InvoiceCurrencyCode: inv.Currency,
+				SpecifiedTradeSettlementHeaderMonetarySummation: ciiMonetarySummation{
+					LineTotalAmount:  formatAmount(lineTotal),                                            // BT-106
+					TaxTotalAmount:   ciiAmount{CurrencyID: inv.Currency, Value: formatAmount(vatTotal)}, // BT-110
+					GrandTotalAmount: formatAmount(taxInclusive),                                         // BT-112
+					DuePayableAmount: formatAmount(taxInclusive),
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("couldn't encode XRechnung invoice: %v", err)
+	}
+	return buf.Bytes(), nil
+}
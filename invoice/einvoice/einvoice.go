@@ -0,0 +1,24 @@
+// Package einvoice exports an invoice.Invoice as a structured electronic
+// invoice document, alongside the human-readable PDF: UBL 2.1 XML,
+// German XRechnung (CII), and ZUGFeRD (the CII XML embedded in the PDF
+// itself). Fields are mapped to their EN 16931 business term (BT-*) and
+// business group (BG-*) identifiers in comments, so the mapping can be
+// checked against the standard directly.
+//
+// BuildUBL and BuildXRechnung read VAT straight off
+// Invoice.Items[].VATRate and Invoice.ShippingVATRate; they don't
+// compute it themselves. Run the invoice through
+// invoice.PDFCreator.ComputeVAT first (RenderInvoice and
+// RenderInvoicePDF already do this internally) — an Invoice fresh out
+// of CreateInvoice or a direct PDF parse has both left at zero, which
+// would silently export a 0% VAT e-invoice.
+package einvoice
+
+import "strconv"
+
+// formatAmount renders a monetary value the way every BT-* amount
+// element in UBL/CII expects: a plain decimal with exactly two digits,
+// no thousands separator or currency symbol.
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,170 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/panda/pdfeditor/invoice"
+)
+
+// ublInvoice is the root of a UBL 2.1 Invoice document. Element tags
+// are written with their literal cac:/cbc: prefixes rather than via
+// xml.Name/namespace plumbing, matching the prefixes declared on the
+// root element below; this is the common shortcut for emitting
+// namespaced XML with encoding/xml without a full namespace-aware model.
+type ublInvoice struct {
+	XMLName  xml.Name `xml:"Invoice"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	XmlnsCac string   `xml:"xmlns:cac,attr"`
+	XmlnsCbc string   `xml:"xmlns:cbc,attr"`
+
+	ID                   string `xml:"cbc:ID"`        // BT-1
+	IssueDate            string `xml:"cbc:IssueDate"` // BT-2
+	InvoiceTypeCode      string `xml:"cbc:InvoiceTypeCode"`
+	DocumentCurrencyCode string `xml:"cbc:DocumentCurrencyCode"`
+
+	AccountingSupplierParty ublPartyWrapper `xml:"cac:AccountingSupplierParty"`
+	AccountingCustomerParty ublPartyWrapper `xml:"cac:AccountingCustomerParty"`
+
+	InvoiceLine []ublLine `xml:"cac:InvoiceLine"` // BG-25
+
+	TaxTotal           ublTaxTotal      `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal ublMonetaryTotal `xml:"cac:LegalMonetaryTotal"`
+}
+
+type ublPartyWrapper struct {
+	Party ublParty `xml:"cac:Party"`
+}
+
+type ublParty struct {
+	PartyName      ublPartyName      `xml:"cac:PartyName"`
+	PostalAddress  ublPostalAddress  `xml:"cac:PostalAddress"`
+	PartyTaxScheme ublPartyTaxScheme `xml:"cac:PartyTaxScheme"`
+}
+
+type ublPartyName struct {
+	Name string `xml:"cbc:Name"`
+}
+
+type ublAddressLine struct {
+	Line string `xml:"cbc:Line"`
+}
+
+type ublPostalAddress struct {
+	AddressLine []ublAddressLine `xml:"cac:AddressLine"`
+	Country     ublCountry       `xml:"cac:Country"`
+}
+
+type ublCountry struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+// ublPartyTaxScheme carries the party's VAT identification number:
+// BT-31 on the supplier, BT-48 on the customer.
+type ublPartyTaxScheme struct {
+	CompanyID string `xml:"cbc:CompanyID"`
+}
+
+// ublLine is one UBL invoice line (BG-25).
+type ublLine struct {
+	ID                  int       `xml:"cbc:ID"`
+	InvoicedQuantity    string    `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount ublAmount `xml:"cbc:LineExtensionAmount"`
+	Item                ublItem   `xml:"cac:Item"`
+	Price               ublPrice  `xml:"cac:Price"`
+}
+
+type ublItem struct {
+	Name string `xml:"cbc:Name"`
+}
+
+type ublPrice struct {
+	PriceAmount ublAmount `xml:"cbc:PriceAmount"`
+}
+
+// ublAmount is a currency-tagged decimal, the shape every BT-* monetary
+// element in UBL takes: <cbc:Foo currencyID="EUR">123.45</cbc:Foo>.
+type ublAmount struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+// ublTaxTotal carries BT-110, the invoice's total VAT amount.
+type ublTaxTotal struct {
+	TaxAmount ublAmount `xml:"cbc:TaxAmount"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtensionAmount ublAmount `xml:"cbc:LineExtensionAmount"` // BT-106
+	TaxExclusiveAmount  ublAmount `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount  ublAmount `xml:"cbc:TaxInclusiveAmount"` // BT-112
+	PayableAmount       ublAmount `xml:"cbc:PayableAmount"`
+}
+
+// BuildUBL renders inv as a UBL 2.1 Invoice document. inv must already
+// have gone through invoice.PDFCreator.ComputeVAT — see the package doc.
+func BuildUBL(inv invoice.Invoice) ([]byte, error) {
+	currency := inv.Currency
+	amount := func(v float64) ublAmount {
+		return ublAmount{CurrencyID: currency, Value: formatAmount(v)}
+	}
+	party := func(p invoice.Party) ublPartyWrapper {
+		lines := make([]ublAddressLine, 0, len(p.Address))
+		for _, l := range p.Address {
+			lines = append(lines, ublAddressLine{Line: l})
+		}
+		return ublPartyWrapper{Party: ublParty{
+			PartyName:      ublPartyName{Name: p.Name},
+			PostalAddress:  ublPostalAddress{AddressLine: lines, Country: ublCountry{IdentificationCode: p.Country}},
+			PartyTaxScheme: ublPartyTaxScheme{CompanyID: p.VATID},
+		}}
+	}
+
+	var vatTotal, lineTotal float64
+	lines := make([]ublLine, 0, len(inv.Items))
+	for i, item := range inv.Items {
+		net := item.Qty * item.UnitPrice
+		lineTotal += net
+		vatTotal += net * item.VATRate
+		lines = append(lines, ublLine{
+			ID:                  i + 1,
+			InvoicedQuantity:    formatAmount(item.Qty),
+			LineExtensionAmount: amount(net),
+			Item:                ublItem{Name: item.Title},
+			Price:               ublPrice{PriceAmount: amount(item.UnitPrice)},
+		})
+	}
+	vatTotal += inv.Shipping * inv.ShippingVATRate
+	taxExclusive := lineTotal + inv.Shipping - inv.Discount
+	taxInclusive := taxExclusive + vatTotal
+
+	doc := ublInvoice{
+		Xmlns:                   "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2",
+		XmlnsCac:                "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2",
+		XmlnsCbc:                "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2",
+		ID:                      inv.Number,
+		IssueDate:               inv.Date.Format("2006-01-02"),
+		InvoiceTypeCode:         "380",
+		DocumentCurrencyCode:    currency,
+		AccountingSupplierParty: party(inv.Seller),
+		AccountingCustomerParty: party(inv.Buyer),
+		InvoiceLine:             lines,
+		TaxTotal:                ublTaxTotal{TaxAmount: amount(vatTotal)},
+		LegalMonetaryTotal: ublMonetaryTotal{
+			LineExtensionAmount: amount(lineTotal),
+			TaxExclusiveAmount:  amount(taxExclusive),
+			TaxInclusiveAmount:  amount(taxInclusive),
+			PayableAmount:       amount(taxInclusive),
+		},
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("couldn't encode UBL invoice: %v", err)
+	}
+	return buf.Bytes(), nil
+}
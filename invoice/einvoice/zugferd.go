@@ -0,0 +1,45 @@
+package einvoice
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// EmbedZUGFeRD embeds xrechnungXML (as produced by BuildXRechnung) into
+// pdf as an attachment named "zugferd-invoice.xml", so a single file is
+// both human-readable (the PDF) and machine-readable (the embedded CII
+// XML), the way ZUGFeRD 2.x / Factur-X invoices work.
+//
+// This embeds the file as a standard PDF attachment but does not attempt
+// full PDF/A-3 conformance: XMP metadata, an ICC output intent, and
+// marking the attachment /AFRelationship /Alternative all need a PDF/A-3
+// profile that pdfcpu (the only pure-Go PDF-editing library available
+// here) doesn't expose. A production ZUGFeRD exporter needs those on
+// top of this to pass a validator.
+func EmbedZUGFeRD(pdf []byte, xrechnungXML []byte) ([]byte, error) {
+	ctx, err := api.ReadValidateAndOptimize(bytes.NewReader(pdf), nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read PDF for ZUGFeRD embedding: %v", err)
+	}
+
+	modTime := time.Now()
+	attachment := model.Attachment{
+		Reader:  bytes.NewReader(xrechnungXML),
+		ID:      "zugferd-invoice.xml",
+		Desc:    "ZUGFeRD/XRechnung CII invoice data",
+		ModTime: &modTime,
+	}
+	if err := ctx.AddAttachment(attachment, false); err != nil {
+		return nil, fmt.Errorf("couldn't embed ZUGFeRD attachment: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := api.Write(ctx, &buf, nil); err != nil {
+		return nil, fmt.Errorf("couldn't write ZUGFeRD PDF: %v", err)
+	}
+	return buf.Bytes(), nil
+}
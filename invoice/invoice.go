@@ -1,16 +1,17 @@
 package invoice
 
-import "C"
 import (
 	"bytes"
 	"embed"
 	"fmt"
 	htmlp "html/template"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -28,6 +29,10 @@ type PDFCreator struct {
 	tmpl       *htmlp.Template
 	images     map[string][]byte
 	reader     func(r *rdpdf.Reader) ([][]string, error)
+	locale     Locale
+	viesClient *VIESClient
+	renderer   Renderer
+	store      Store
 	pdfObjList []struct {
 		path   string
 		pdfObj *pdf
@@ -35,23 +40,54 @@ type PDFCreator struct {
 }
 
 type pdf struct {
-	params map[string]interface{}
-	tmpl   *htmlp.Template
-	images map[string][]byte
-	reader func(r *rdpdf.Reader) ([][]string, error)
+	params     map[string]interface{}
+	tmpl       *htmlp.Template
+	images     map[string][]byte
+	reader     func(r *rdpdf.Reader) ([][]string, error)
+	locale     Locale
+	invoice    Invoice
+	viesClient *VIESClient
+	renderer   Renderer
 }
 
-func New(readerFun func(r *rdpdf.Reader) ([][]string, error)) (*PDFCreator, error) {
+// New creates a PDFCreator that parses and re-renders invoices in the
+// given language (e.g. "en", "de", "pl"). The language selects both the
+// labels used to parse the source PDF and the invoice.<lang>.pdf-html.tmpl
+// template used to re-render it.
+func New(readerFun func(r *rdpdf.Reader) ([][]string, error), lang string) (*PDFCreator, error) {
+	locale, err := getLocale(lang)
+	if err != nil {
+		return nil, err
+	}
 	c := &PDFCreator{
-		reader: readerFun,
+		reader:   readerFun,
+		locale:   locale,
+		renderer: defaultRenderer(),
 	}
-	err := c.loadInvoiceTemplate()
-	if err != nil {
+	if err := c.loadInvoiceTemplate(); err != nil {
 		return nil, err
 	}
 	return c, nil
 }
 
+// WithVIESClient enables VIES validation of buyer VAT IDs before
+// granting reverse charge. Without one, a VAT ID is accepted at face
+// value once it has the right shape.
+func (c *PDFCreator) WithVIESClient(v *VIESClient) *PDFCreator {
+	c.viesClient = v
+	return c
+}
+
+// WithRenderer overrides the PDF backend. New defaults to WKHTMLRenderer
+// on cgo-enabled builds (main.go already calls wkpdf.Init()/Destroy())
+// and to NativeRenderer otherwise, since WKHTMLRenderer isn't compiled
+// in without cgo; pass a NewNativeRenderer() explicitly to opt out of
+// wkhtmltopdf on a cgo build too.
+func (c *PDFCreator) WithRenderer(r Renderer) *PDFCreator {
+	c.renderer = r
+	return c
+}
+
 func (c *PDFCreator) loadInvoiceTemplate() error {
 	htmlFS, err := fs.Sub(tmpFS, "template")
 	if err != nil {
@@ -95,10 +131,13 @@ func (c *PDFCreator) RecreatePDF() error {
 			return nil
 		}
 		pdfObj := &pdf{
-			params: make(map[string]interface{}),
-			tmpl:   c.tmpl,
-			images: c.images,
-			reader: c.reader,
+			params:     make(map[string]interface{}),
+			tmpl:       c.tmpl,
+			images:     c.images,
+			reader:     c.reader,
+			locale:     c.locale,
+			viesClient: c.viesClient,
+			renderer:   c.renderer,
 		}
 		err = pdfObj.parseParamsFromPDF(path)
 		if err != nil {
@@ -118,6 +157,14 @@ func (c *PDFCreator) RecreatePDF() error {
 	return nil
 }
 
+// parseLocaleFloat parses a German/Polish/EU-style number (thousands
+// separator ".", decimal separator ",", e.g. "1.234,56") into a float64.
+func parseLocaleFloat(s string) (float64, error) {
+	s = strings.ReplaceAll(s, ".", "")
+	s = strings.ReplaceAll(s, ",", ".")
+	return strconv.ParseFloat(s, 64)
+}
+
 func compareStringArray(a []string, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -130,29 +177,9 @@ func compareStringArray(a []string, b []string) bool {
 	return true
 }
 
-var pdfHead = []string{
-	"MatchX GmbH",
-	"Brückenstraße 4",
-	"10179 Berlin",
-	"Germany",
-	"Tax number: 37/436/50071",
-	"awesome@matchx.io",
-	"VAT ID: DE309834893",
-	"INVOICE NUMBER MUST BE INCLUDED WITH YOUR BANK PAYMENT OTHERWISE DELAYS",
-	"MAY OCCUR",
-	"1 of 1",
-}
-var pdfPaymentInfo = []string{
-	"Payment details:",
-	"Payment must be made within 30 days from issue date.",
-	"BIC: PBNKDEFF",
-	"IBAN: DE12 1001 0010 0685 1601 27",
-	"BANK: Post Bank",
-	"ACCOUNT HOLDER: MatchX GmbH",
-	"PayPal: info@matchx.io",
-}
-
 func (p *pdf) parseParamsFromPDF(path string) error {
+	pdfHead := pdfHeadFor(p.locale)
+	pdfPaymentInfo := pdfPaymentInfoFor(p.locale)
 	f, r, err := rdpdf.Open(filepath.Join("invoice", path))
 	defer func() {
 		_ = f.Close()
@@ -180,14 +207,14 @@ func (p *pdf) parseParamsFromPDF(path string) error {
 		if row[nextIdx] != "Invoice" {
 			return fmt.Errorf("not able to parse format at row %s, expect \"Invoice\"", row[nextIdx])
 		}
-		if row[nextIdx+1] == "PAID" {
+		if row[nextIdx+1] == p.locale.Status["PAID"] {
 			p.params["Status"] = "PAID"
 			nextIdx += 2
 		}
 		// match invoice details
 		oldIdx := nextIdx
 		for i := 0; i < len(row); i++ {
-			if row[nextIdx+i] == "Bill to:" {
+			if row[nextIdx+i] == p.locale.BillTo {
 				nextIdx += i + 1
 				break
 			}
@@ -195,13 +222,13 @@ func (p *pdf) parseParamsFromPDF(path string) error {
 		}
 		p.params["InvoiceDetailsList"] = invoiceDetails
 		if oldIdx == nextIdx {
-			return fmt.Errorf("not able to detect \"Bill to:\"")
+			return fmt.Errorf("not able to detect %q", p.locale.BillTo)
 		}
 
 		// match bill to
 		oldIdx = nextIdx
 		for i := 0; i < len(row); i++ {
-			if row[nextIdx+i] == "Ship to:" {
+			if row[nextIdx+i] == p.locale.ShipTo {
 				nextIdx += i + 1
 				break
 			}
@@ -210,12 +237,12 @@ func (p *pdf) parseParamsFromPDF(path string) error {
 		p.params["BillToList"] = billTo
 		// didn't match beginning of ship to
 		if oldIdx == nextIdx {
-			return fmt.Errorf("not able to detect \"Ship to:\"")
+			return fmt.Errorf("not able to detect %q", p.locale.ShipTo)
 		}
 		// match ship to
 		oldIdx = nextIdx
 		for i := 0; i < len(row); i++ {
-			if row[nextIdx+i] == "Description" {
+			if row[nextIdx+i] == p.locale.Description {
 				nextIdx += i + 1
 				break
 			}
@@ -223,8 +250,22 @@ func (p *pdf) parseParamsFromPDF(path string) error {
 		}
 		p.params["ShipToList"] = shipTo
 		if oldIdx == nextIdx {
-			return fmt.Errorf("not able to detect \"Description\"")
+			return fmt.Errorf("not able to detect %q", p.locale.Description)
+		}
+
+		// match the item table: "Qty"/"Unit Price"/"Line Total" mark its
+		// header row, which is followed by one {title, qty, unit price,
+		// line total} tuple per item, up to the Subtotal anchor.
+		if !compareStringArray(row[nextIdx:nextIdx+3], []string{p.locale.Qty, p.locale.UnitPrice, p.locale.LineTotal}) {
+			return fmt.Errorf("not able to parse item table header")
+		}
+		nextIdx += 3
+		var items []InvoiceItem
+		items, nextIdx, err = p.parseItemRows(row, nextIdx)
+		if err != nil {
+			return err
 		}
+		p.params["Items"] = items
 
 		// match payment details
 		oldIdx = nextIdx
@@ -233,19 +274,15 @@ func (p *pdf) parseParamsFromPDF(path string) error {
 				nextIdx = i
 				break
 			}
-			if row[i] == "Qty" {
-				p.params["Description"] = row[i+2]
-				p.params["Quantity"] = row[i+3]
-			}
-			if row[i] == "Discount:" {
+			if row[i] == p.locale.Discount {
 				p.params["Discount"] = strings.ReplaceAll(row[i+1], ".", "")
 				p.params["Discount"] = strings.ReplaceAll(p.params["Discount"].(string), ",", ".")
 			}
-			if row[i] == "Shipping:" {
+			if row[i] == p.locale.Shipping {
 				p.params["Shipping"] = strings.ReplaceAll(row[i+1], ".", "")
 				p.params["Shipping"] = strings.ReplaceAll(p.params["Shipping"].(string), ",", ".")
 			}
-			if row[i] == "Subtotal:" {
+			if row[i] == p.locale.Subtotal {
 				p.params["GatewayTotalPrice"] = strings.ReplaceAll(row[i+1], ".", "")
 				p.params["GatewayTotalPrice"] = strings.ReplaceAll(p.params["GatewayTotalPrice"].(string), ",", ".")
 			}
@@ -258,12 +295,100 @@ func (p *pdf) parseParamsFromPDF(path string) error {
 			return fmt.Errorf("not able to parse payment info")
 		}
 	}
-	return nil
+	return p.buildInvoice()
+}
+
+// parseItemRows reads the {title, qty, unit price, line total} tuples
+// starting at nextIdx, up to (not including) the Subtotal anchor, and
+// returns the parsed items along with the index of that anchor. It
+// returns an error, rather than running off the end of row, if the
+// table is truncated before a Subtotal row is ever found.
+func (p *pdf) parseItemRows(row []string, nextIdx int) ([]InvoiceItem, int, error) {
+	var items []InvoiceItem
+	for nextIdx < len(row) && row[nextIdx] != p.locale.Subtotal {
+		if nextIdx+3 >= len(row) {
+			return nil, nextIdx, fmt.Errorf("truncated item row at position %d", nextIdx)
+		}
+		qty, err := parseLocaleFloat(row[nextIdx+1])
+		if err != nil {
+			return nil, nextIdx, fmt.Errorf("couldn't parse item quantity %q: %v", row[nextIdx+1], err)
+		}
+		unitPrice, err := parseLocaleFloat(row[nextIdx+2])
+		if err != nil {
+			return nil, nextIdx, fmt.Errorf("couldn't parse item unit price %q: %v", row[nextIdx+2], err)
+		}
+		lineTotal, err := parseLocaleFloat(row[nextIdx+3])
+		if err != nil {
+			return nil, nextIdx, fmt.Errorf("couldn't parse item line total %q: %v", row[nextIdx+3], err)
+		}
+		if math.Abs(lineTotal-qty*unitPrice) > 0.01 {
+			return nil, nextIdx, fmt.Errorf("line total %.2f doesn't match qty*unit price %.2f for %q", lineTotal, qty*unitPrice, row[nextIdx])
+		}
+		items = append(items, InvoiceItem{Title: row[nextIdx], Qty: qty, UnitPrice: unitPrice, LineTotal: lineTotal})
+		nextIdx += 4
+	}
+	if nextIdx >= len(row) {
+		return nil, nextIdx, fmt.Errorf("item table never reached %q", p.locale.Subtotal)
+	}
+	return items, nextIdx, nil
 }
 
-type vat struct {
-	rateUnderLimit float64
-	rateOverLimit  float64
+// buildInvoice turns the raw strings gathered by parseParamsFromPDF into
+// the strongly-typed Invoice that regenerateInvoicePDF computes VAT and
+// totals from.
+func (p *pdf) buildInvoice() error {
+	billTo, _ := p.params["BillToList"].([]string)
+	var country, vatID string
+	if len(billTo) > 0 {
+		country = billTo[len(billTo)-1]
+	}
+	for _, line := range billTo {
+		if strings.Contains(line, "VAT Number:") {
+			vatID = strings.TrimSpace(strings.TrimPrefix(line, "VAT Number:"))
+		}
+	}
+
+	var discount, shipping float64
+	var err error
+	if v, ok := p.params["Discount"].(string); ok && v != "" {
+		if discount, err = strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("couldn't parse discount %q: %v", v, err)
+		}
+	}
+	if v, ok := p.params["Shipping"].(string); ok && v != "" && v != p.locale.FreeShipping {
+		if shipping, err = strconv.ParseFloat(v, 64); err != nil {
+			return fmt.Errorf("couldn't parse shipping %q: %v", v, err)
+		}
+	}
+
+	items, _ := p.params["Items"].([]InvoiceItem)
+
+	status := StatusDraft
+	if p.params["Status"] == "PAID" {
+		status = StatusPaid
+	}
+
+	p.invoice = Invoice{
+		Seller: Party{
+			Name:    "MatchX GmbH",
+			Address: []string{"Brückenstraße 4", "10179 Berlin", "Germany"},
+			Country: "Germany",
+			VATID:   "DE309834893",
+		},
+		Buyer: Party{
+			Address: billTo,
+			Country: country,
+			VATID:   vatID,
+		},
+		Items:    items,
+		Discount: discount,
+		Shipping: shipping,
+		IBAN:     "DE12 1001 0010 0685 1601 27",
+		SWIFT:    "PBNKDEFF",
+		Currency: "EUR",
+		Status:   status,
+	}
+	return nil
 }
 
 func (p *pdf) getSubTmpl(name string, paramKey string) error {
@@ -285,105 +410,130 @@ func (p *pdf) getSubTmpl(name string, paramKey string) error {
 	return nil
 }
 
-var vatMap = map[string]*vat{
-	"Germany":        {rateUnderLimit: 0.19, rateOverLimit: 0.19},
-	"Netherlands":    {rateUnderLimit: 0.19, rateOverLimit: 0.21},
-	"Austria":        {rateUnderLimit: 0.19, rateOverLimit: 0.20},
-	"Belgium":        {rateUnderLimit: 0.19, rateOverLimit: 0.21},
-	"Bulgaria":       {rateUnderLimit: 0.19, rateOverLimit: 0.20},
-	"Croatia":        {rateUnderLimit: 0.19, rateOverLimit: 0.25},
-	"Cyprus":         {rateUnderLimit: 0.19, rateOverLimit: 0.19},
-	"Czech Republic": {rateUnderLimit: 0.19, rateOverLimit: 0.21},
-	"Denmark":        {rateUnderLimit: 0.19, rateOverLimit: 0.25},
-	"Estonia":        {rateUnderLimit: 0.19, rateOverLimit: 0.20},
-	"Finland":        {rateUnderLimit: 0.19, rateOverLimit: 0.24},
-	"France":         {rateUnderLimit: 0.19, rateOverLimit: 0.20},
-	"Greece":         {rateUnderLimit: 0.19, rateOverLimit: 0.24},
-	"Hungary":        {rateUnderLimit: 0.19, rateOverLimit: 0.27},
-	"Ireland":        {rateUnderLimit: 0.19, rateOverLimit: 0.23},
-	"Italy":          {rateUnderLimit: 0.19, rateOverLimit: 0.22},
-	"Latvia":         {rateUnderLimit: 0.19, rateOverLimit: 0.21},
-	"Lithuania":      {rateUnderLimit: 0.19, rateOverLimit: 0.21},
-	"Luxembourg":     {rateUnderLimit: 0.19, rateOverLimit: 0.17},
-	"Malta":          {rateUnderLimit: 0.19, rateOverLimit: 0.18},
-	"Monaco":         {rateUnderLimit: 0.19, rateOverLimit: 0.20},
-	"Poland":         {rateUnderLimit: 0.19, rateOverLimit: 0.23},
-	"Portugal":       {rateUnderLimit: 0.19, rateOverLimit: 0.23},
-	"Romania":        {rateUnderLimit: 0.19, rateOverLimit: 0.19},
-	"Slovakia":       {rateUnderLimit: 0.19, rateOverLimit: 0.20},
-	"Slovenia":       {rateUnderLimit: 0.19, rateOverLimit: 0.22},
-	"Spain":          {rateUnderLimit: 0.19, rateOverLimit: 0.21},
-	"Sweden":         {rateUnderLimit: 0.19, rateOverLimit: 0.25},
-	"UK":             {rateUnderLimit: 0.19, rateOverLimit: 0.20},
-}
-var vatCountryCode = map[string]string{
-	"DE": "Germany",
-	"NL": "Netherlands",
-	"AT": "Austria",
-	"BE": "Belgium",
-	"BG": "Bulgaria",
-	"HR": "Croatia",
-	"CY": "Cyprus",
-	"CZ": "Czech Republic",
-	"DK": "Denmark",
-	"EE": "Estonia",
-	"FI": "Finland",
-	"FR": "France",
-	"EL": "Greece",
-	"HU": "Hungary",
-	"IE": "Ireland",
-	"IT": "Italy",
-	"LV": "Latvia",
-	"LT": "Lithuania",
-	"LU": "Luxembourg",
-	"MT": "Malta",
-	"PL": "Poland",
-	"PT": "Portugal",
-	"RO": "Romania",
-	"SK": "Slovakia",
-	"SI": "Slovenia",
-	"ES": "Spain",
-	"SE": "Sweden",
-	"GB": "UK",
-	"XI": "UK",
-}
-
-func getVATRate(country string) *vat {
-	fmt.Println(country)
-	if strings.Contains(country, "VAT Number:") {
-		country = vatCountryCode[country[12:14]]
+func (p *pdf) regenerateInvoicePDF(path string) error {
+	if err := p.renderInvoiceParams(); err != nil {
+		return err
 	}
-	for k, v := range vatMap {
-		if strings.Contains(country, k) {
-			return v
+	html, err := p.renderHTML()
+	if err != nil {
+		return err
+	}
+	buff := bytes.NewBuffer(html)
+
+	// Convert objects and save the output PDF document.
+	f, err := os.Stat(filepath.Join("invoice", "new"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	} else if (err == nil && !f.IsDir()) || (err != nil && os.IsNotExist(err)) {
+		if err := os.MkdirAll(filepath.Join("invoice", "new"), os.FileMode(0755)); err != nil {
+			return err
 		}
 	}
-	return vatMap["Germany"]
+	outFile, err := os.Create(filepath.Join("invoice", "new", fmt.Sprintf("%s.html", filepath.Base(path))))
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %v", path, err)
+	}
+	defer outFile.Close()
+	if _, err = outFile.Write(buff.Bytes()); err != nil {
+		return err
+	}
+	if err = outFile.Sync(); err != nil {
+		return err
+	}
+
+	if p.renderer == nil {
+		return nil
+	}
+
+	pdfBytes, err := p.renderer.Render(html, p.invoice)
+	if err != nil {
+		return fmt.Errorf("failed to render PDF for %s: %v", path, err)
+	}
+	pdfFile, err := os.Create(filepath.Join("invoice", "new", fmt.Sprintf("%s.pdf", filepath.Base(path))))
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %v", path, err)
+	}
+	defer pdfFile.Close()
+	if _, err = pdfFile.Write(pdfBytes); err != nil {
+		return err
+	}
+	return pdfFile.Sync()
 }
 
-func (p *pdf) regenerateInvoicePDF(path string) error {
-	var err error
-	var gatewayTotalPrice, discount, shipping float64
+// displayItem is an InvoiceItem formatted for the pdf-html.tmpl
+// {{range .Items}} block: amounts already carry the locale's money
+// formatting, so the template just interpolates them.
+type displayItem struct {
+	Title     string
+	Qty       string
+	UnitPrice string
+	LineTotal string
+}
+
+// renderInvoiceParams computes VAT and totals off p.invoice and fills in
+// the remaining p.params fields renderHTML's template needs.
+// The item table and everything below it (totals, reverse charge note,
+// payment details) is laid out with absolute pixel offsets. With a
+// fixed number of items those offsets are constants; with more items
+// than that they'd run into each other, so everything from the
+// Subtotal line down is shifted by however far the table grows past
+// its baseline height.
+const (
+	itemsTableTop      = 340
+	itemsHeaderHeight  = 20
+	itemsRowHeight     = 16
+	itemsTableGap      = 20
+	baseSubtotalTop    = 560
+	baseDiscountTop    = 592
+	baseShippingTop    = 610
+	baseVATTop         = 628
+	baseTotalExclTop   = 646
+	baseTotalTop       = 664
+	baseReverseTop     = 700
+	basePaymentTop     = 760
+	basePaymentDueTop  = 776
+	baseBICTop         = 792
+	baseIBANTop        = 806
+	baseBankTop        = 820
+	baseAccountHldrTop = 834
+	basePayPalTop      = 848
+)
+
+func (p *pdf) renderInvoiceParams() error {
 	ac := accounting.Accounting{
 		Symbol:    "€",
 		Precision: 2,
 		Thousand:  ".",
 		Decimal:   ",",
 	}
-	if p.params["GatewayTotalPrice"] != nil {
-		gatewayTotalPrice, err = strconv.ParseFloat(p.params["GatewayTotalPrice"].(string), 64)
-		if err != nil {
-			return err
-		}
+	gatewayTotalPrice := p.invoice.Subtotal()
+	discount := p.invoice.Discount
+	shipping := p.invoice.Shipping
+
+	itemsBottom := itemsTableTop + itemsHeaderHeight + len(p.invoice.Items)*itemsRowHeight
+	shift := 0
+	if itemsBottom+itemsTableGap > baseSubtotalTop {
+		shift = itemsBottom + itemsTableGap - baseSubtotalTop
 	}
-	if p.params["Discount"] != nil {
-		discount, err = strconv.ParseFloat(p.params["Discount"].(string), 64)
-		if err != nil {
-			return err
-		}
+	p.params["SubtotalTop"] = baseSubtotalTop + shift
+	p.params["DiscountTop"] = baseDiscountTop + shift
+	p.params["ShippingTop"] = baseShippingTop + shift
+	p.params["VATTop"] = baseVATTop + shift
+	p.params["TotalExclVATTop"] = baseTotalExclTop + shift
+	p.params["TotalTop"] = baseTotalTop + shift
+	p.params["ReverseChargeTop"] = baseReverseTop + shift
+	p.params["PaymentDetailsTop"] = basePaymentTop + shift
+	p.params["PaymentDueTop"] = basePaymentDueTop + shift
+	p.params["BICTop"] = baseBICTop + shift
+	p.params["IBANTop"] = baseIBANTop + shift
+	p.params["BankTop"] = baseBankTop + shift
+	p.params["AccountHolderTop"] = baseAccountHldrTop + shift
+	p.params["PayPalTop"] = basePayPalTop + shift
+
+	if discount != 0 {
 		tmpl, err := htmlp.New("discount").Parse(`
-<p style="position:absolute;top:592px;left:452px;white-space:nowrap" class="ft10">Discount:</p>
-<p style="position:absolute;top:592px;left:741px;white-space:nowrap" class="ft10">{{ .Discount }}</p>`)
+<p style="position:absolute;top:{{ .DiscountTop }}px;left:452px;white-space:nowrap" class="ft10">Discount:</p>
+<p style="position:absolute;top:{{ .DiscountTop }}px;left:741px;white-space:nowrap" class="ft10">{{ .Discount }}</p>`)
 		if err != nil {
 			return err
 		}
@@ -394,78 +544,208 @@ func (p *pdf) regenerateInvoicePDF(path string) error {
 		}
 		p.params["Discount"] = htmlp.HTML(buff.String())
 	}
-	if p.params["Shipping"] != nil {
-		if p.params["Shipping"].(string) == "Free shipping" {
-			shipping = 0
-		} else {
-			shipping, err = strconv.ParseFloat(p.params["Shipping"].(string), 64)
-			if err != nil {
-				return err
-			}
-		}
-	}
 
-	country := p.params["BillToList"].([]string)[len(p.params["BillToList"].([]string))-1]
-	vatRate := getVATRate(country)
-	if vatRate == nil {
-		return fmt.Errorf("no vat rate found for billing address: %s", strings.Join(p.params["BillToList"].([]string), "\n"))
+	rate, reverseCharge, err := p.applyVATRates()
+	if err != nil {
+		return err
+	}
+	if reverseCharge {
+		tmpl, err := htmlp.New("reverseCharge").Parse(`
+<p style="position:absolute;top:{{ .ReverseChargeTop }}px;left:40px;white-space:nowrap" class="ft10">Reverse charge &mdash; VAT to be accounted for by the recipient (Art. 196 Directive 2006/112/EC)</p>`)
+		if err != nil {
+			return err
+		}
+		buff := bytes.NewBuffer(nil)
+		if err := tmpl.ExecuteTemplate(buff, "reverseCharge", p.params); err != nil {
+			return err
+		}
+		p.params["ReverseCharge"] = htmlp.HTML(buff.String())
 	}
-	if err = p.getSubTmpl("BillTo", "BillToList"); err != nil {
+	if err := p.getSubTmpl("BillTo", "BillToList"); err != nil {
 		return err
 	}
-	if err = p.getSubTmpl("ShipTo", "ShipToList"); err != nil {
+	if err := p.getSubTmpl("ShipTo", "ShipToList"); err != nil {
 		return err
 	}
-	if err = p.getSubTmpl("InvoiceDetails", "InvoiceDetailsList"); err != nil {
+	if err := p.getSubTmpl("InvoiceDetails", "InvoiceDetailsList"); err != nil {
 		return err
 	}
 
-	vatTotal := gatewayTotalPrice * vatRate.rateUnderLimit
+	// VAT is computed per line rather than once for the whole invoice,
+	// so an invoice can mix rates (e.g. reduced-rate shipping next to
+	// standard-rate hardware); applyVATRates has already filled in the
+	// rate actually charged on each item and on shipping.
+	var vatTotal float64
+	items := make([]displayItem, 0, len(p.invoice.Items))
+	for _, item := range p.invoice.Items {
+		lineTotal := item.Qty * item.UnitPrice
+		vatTotal += lineTotal * item.VATRate
+		items = append(items, displayItem{
+			Title:     item.Title,
+			Qty:       strconv.FormatFloat(item.Qty, 'f', -1, 64),
+			UnitPrice: ac.FormatMoney(item.UnitPrice),
+			LineTotal: ac.FormatMoney(lineTotal),
+		})
+	}
+	p.params["Items"] = items
+	vatTotal += shipping * p.invoice.ShippingVATRate
+
 	gatewayPriceWithoutVAT := gatewayTotalPrice - vatTotal
 	totalExclVAT := gatewayTotalPrice + shipping - discount
 	total := totalExclVAT + vatTotal
 
 	if shipping == 0 {
-		p.params["Shipping"] = "Free shipping"
+		p.params["Shipping"] = p.locale.FreeShipping
 	} else {
 		p.params["Shipping"] = ac.FormatMoney(shipping)
 	}
 
 	p.params["GatewayTotalPrice"] = ac.FormatMoney(gatewayPriceWithoutVAT)
 	p.params["VATTotal"] = ac.FormatMoney(vatTotal)
-	p.params["VATPercentage"] = fmt.Sprintf("%s%%", strconv.FormatFloat(vatRate.rateUnderLimit*100, 'f', 2, 64))
+	p.params["VATPercentage"] = fmt.Sprintf("%s%%", strconv.FormatFloat(rate*100, 'f', 2, 64))
 	p.params["TotalExclVAT"] = ac.FormatMoney(totalExclVAT)
 	p.params["Total"] = ac.FormatMoney(total)
+	return nil
+}
 
-	template := p.tmpl.Lookup("invoice.pdf-html.tmpl")
+// renderHTML executes the locale's template against p.params and
+// returns the resulting HTML. It assumes regenerateInvoicePDF has
+// already populated p.params with the computed totals and sub-templates.
+func (p *pdf) renderHTML() ([]byte, error) {
+	tmplName := fmt.Sprintf("invoice.%s.pdf-html.tmpl", p.locale.Code)
+	template := p.tmpl.Lookup(tmplName)
 	if template == nil {
-		return fmt.Errorf("template invoice.pdf-html.tmpl not found")
+		return nil, fmt.Errorf("template %s not found", tmplName)
 	}
 	buff := bytes.NewBuffer(nil)
 	if err := template.Execute(buff, p.params); err != nil {
-		return fmt.Errorf("failed to render template invoice.pdf-html.tmpl: %v", err)
+		return nil, fmt.Errorf("failed to render template %s: %v", tmplName, err)
 	}
+	return buff.Bytes(), nil
+}
 
-	// Convert objects and save the output PDF document.
-	f, err := os.Stat(filepath.Join("invoice", "new"))
-	if err != nil && !os.IsNotExist(err) {
-		return err
-	} else if (err == nil && !f.IsDir()) || (err != nil && os.IsNotExist(err)) {
-		if err := os.MkdirAll(filepath.Join("invoice", "new"), os.FileMode(0755)); err != nil {
-			return err
-		}
+// RenderInvoice renders a structured Invoice directly to HTML, without
+// a source PDF to parse. It is the entry point invoiceservice.Server
+// uses for its RenderInvoice RPC.
+func (c *PDFCreator) RenderInvoice(inv Invoice) ([]byte, error) {
+	html, _, err := c.renderInvoiceHTML(inv, "")
+	return html, err
+}
+
+// RenderProforma renders inv as a draft, watermarked invoice: it carries
+// a provisional "PROFORMA" number and no issue date, since neither is
+// final until Seal assigns them. Re-rendering a proforma is always
+// side-effect free; only Seal touches persistent state.
+func (c *PDFCreator) RenderProforma(inv Invoice) ([]byte, error) {
+	inv.Number = "PROFORMA"
+	inv.Date = time.Time{}
+	inv.Status = StatusDraft
+	html, _, err := c.renderInvoiceHTML(inv, "PROFORMA")
+	return html, err
+}
+
+// RenderInvoicePDF renders inv straight to PDF bytes through the
+// configured Renderer, computing VAT the same way RenderInvoice does
+// (including any per-line VATRate the renderer needs to print). It is
+// the PDF counterpart of RenderInvoice, for callers of the structured
+// Invoice/gRPC path who don't go through PDFCreator.RecreatePDF.
+func (c *PDFCreator) RenderInvoicePDF(inv Invoice) ([]byte, error) {
+	return c.renderPDF(inv, "")
+}
+
+// RenderProformaPDF is the PDF counterpart of RenderProforma: the same
+// draft, watermarked invoice, rendered through the configured Renderer
+// instead of to HTML.
+func (c *PDFCreator) RenderProformaPDF(inv Invoice) ([]byte, error) {
+	inv.Number = "PROFORMA"
+	inv.Date = time.Time{}
+	inv.Status = StatusDraft
+	return c.renderPDF(inv, "PROFORMA")
+}
+
+func (c *PDFCreator) renderPDF(inv Invoice, watermark string) ([]byte, error) {
+	if c.renderer == nil {
+		return nil, fmt.Errorf("no renderer configured")
 	}
-	outFile, err := os.Create(filepath.Join("invoice", "new", fmt.Sprintf("%s.html", filepath.Base(path))))
+	html, computed, err := c.renderInvoiceHTML(inv, watermark)
 	if err != nil {
-		return fmt.Errorf("failed to create file %s: %v", path, err)
+		return nil, err
 	}
-	defer outFile.Close()
-	if _, err = outFile.Write(buff.Bytes()); err != nil {
-		return err
+	return c.renderer.Render(html, computed)
+}
+
+// ComputeVAT works out the VAT rate actually charged on each of inv's
+// items and on its shipping (the same computation RenderInvoice and
+// RenderInvoicePDF run before rendering) and returns inv with those
+// rates filled in. A freshly created or directly-parsed Invoice has
+// VATRate left at zero; callers that need VAT-aware output without
+// rendering a PDF or HTML at all — einvoice.BuildUBL and
+// einvoice.BuildXRechnung, notably — must run it through this first.
+func (c *PDFCreator) ComputeVAT(inv Invoice) (Invoice, error) {
+	p := &pdf{
+		locale:     c.locale,
+		invoice:    inv,
+		viesClient: c.viesClient,
 	}
-	if err = outFile.Sync(); err != nil {
-		return err
+	if _, _, err := p.applyVATRates(); err != nil {
+		return Invoice{}, err
 	}
+	return p.invoice, nil
+}
 
-	return nil
+// renderInvoiceHTML renders inv to HTML and returns the invoice as
+// renderInvoiceParams left it: items carry the VATRate actually charged,
+// even when the caller didn't set one explicitly.
+func (c *PDFCreator) renderInvoiceHTML(inv Invoice, watermark string) ([]byte, Invoice, error) {
+	p := &pdf{
+		params:     make(map[string]interface{}),
+		tmpl:       c.tmpl,
+		images:     c.images,
+		locale:     c.locale,
+		invoice:    inv,
+		viesClient: c.viesClient,
+	}
+	p.params["BillToList"] = inv.Buyer.Address
+	p.params["ShipToList"] = inv.Buyer.Address
+	issueDate := "no issue date yet"
+	if !inv.Date.IsZero() {
+		issueDate = inv.Date.Format("2006-01-02")
+	}
+	p.params["InvoiceDetailsList"] = []string{inv.Number, issueDate}
+	if inv.Status == StatusPaid {
+		p.params["Status"] = "PAID"
+	}
+	if watermark != "" {
+		p.params["Watermark"] = watermark
+	}
+	if err := p.renderInvoiceParams(); err != nil {
+		return nil, Invoice{}, err
+	}
+	html, err := p.renderHTML()
+	if err != nil {
+		return nil, Invoice{}, err
+	}
+	return html, p.invoice, nil
+}
+
+// WithStore gives PDFCreator a place to persist invoices, enabling the
+// proforma/Seal two-phase lifecycle: Seal looks the draft invoice up by
+// id, assigns it a final number and freezes its issue date there.
+func (c *PDFCreator) WithStore(s Store) *PDFCreator {
+	c.store = s
+	return c
+}
+
+// Seal commits the draft invoice identified by id: it assigns the next
+// sequential invoice number from the store's persistent per-year
+// counter (e.g. "2024/001"), freezes the issue date to now, and marks
+// the invoice issued. Sealing an invoice that's already sealed is a
+// no-op: it returns the number assigned the first time rather than
+// minting a new one, so re-running the tool stays idempotent. Callers
+// re-render the result with RenderInvoice to get the final PDF.
+func (c *PDFCreator) Seal(id string) (Invoice, error) {
+	if c.store == nil {
+		return Invoice{}, fmt.Errorf("no store configured for sealing invoices")
+	}
+	return c.store.SealInvoice(id, time.Now())
 }
@@ -0,0 +1,35 @@
+package invoice
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseItemRowsNeverReachesSubtotal(t *testing.T) {
+	p := pdf{locale: Locale{Subtotal: "Subtotal:"}}
+	row := []string{"Book", "1", "10.00", "10.00", "Hardware", "1", "100.00", "100.00"}
+
+	_, _, err := p.parseItemRows(row, 0)
+	if err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+	if !strings.Contains(err.Error(), "Subtotal:") {
+		t.Errorf("got error %q, want it to mention the missing Subtotal anchor", err)
+	}
+}
+
+func TestParseItemRowsStopsAtSubtotal(t *testing.T) {
+	p := pdf{locale: Locale{Subtotal: "Subtotal:"}}
+	row := []string{"Book", "1", "10.00", "10.00", "Subtotal:", "10.00"}
+
+	items, nextIdx, err := p.parseItemRows(row, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 || items[0].Title != "Book" {
+		t.Fatalf("got items %+v, want a single Book item", items)
+	}
+	if row[nextIdx] != "Subtotal:" {
+		t.Errorf("got nextIdx %d (row[nextIdx]=%q), want it to point at Subtotal:", nextIdx, row[nextIdx])
+	}
+}
@@ -0,0 +1,61 @@
+package invoice
+
+import "testing"
+
+func TestApplyVATRatesPerLineOverrides(t *testing.T) {
+	p := pdf{invoice: Invoice{
+		Seller: Party{Country: "Germany"},
+		Buyer:  Party{Country: "Germany"},
+		Items: []InvoiceItem{
+			{Title: "Book", Qty: 1, UnitPrice: 10, VATRate: 0.07},
+			{Title: "Hardware", Qty: 1, UnitPrice: 100},
+		},
+		Shipping:        5,
+		ShippingVATRate: 0.07,
+	}}
+
+	rate, reverseCharge, err := p.applyVATRates()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reverseCharge {
+		t.Fatalf("domestic sale shouldn't trigger reverse charge")
+	}
+	if rate != 0.19 {
+		t.Fatalf("got base rate %v, want 0.19", rate)
+	}
+	if p.invoice.Items[0].VATRate != 0.07 {
+		t.Errorf("line override should win: got %v, want 0.07", p.invoice.Items[0].VATRate)
+	}
+	if p.invoice.Items[1].VATRate != 0.19 {
+		t.Errorf("unset line should fall back to the invoice rate: got %v, want 0.19", p.invoice.Items[1].VATRate)
+	}
+	if p.invoice.ShippingVATRate != 0.07 {
+		t.Errorf("shipping override should win: got %v, want 0.07", p.invoice.ShippingVATRate)
+	}
+}
+
+func TestApplyVATRatesReverseChargeZeroesEverything(t *testing.T) {
+	p := pdf{invoice: Invoice{
+		Seller: Party{Country: "Germany"},
+		Buyer:  Party{Country: "Netherlands", VATID: "NL809977163B01"},
+		Items: []InvoiceItem{
+			{Title: "Hardware", Qty: 1, UnitPrice: 100, VATRate: 0.21},
+		},
+		Shipping:        5,
+		ShippingVATRate: 0.21,
+	}}
+	_, reverseCharge, err := p.applyVATRates()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reverseCharge {
+		t.Fatalf("expected reverse charge")
+	}
+	if p.invoice.Items[0].VATRate != 0 {
+		t.Errorf("reverse charge should zero even an explicit line override: got %v", p.invoice.Items[0].VATRate)
+	}
+	if p.invoice.ShippingVATRate != 0 {
+		t.Errorf("reverse charge should zero shipping VAT too: got %v", p.invoice.ShippingVATRate)
+	}
+}
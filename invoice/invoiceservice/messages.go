@@ -0,0 +1,55 @@
+package invoiceservice
+
+import "github.com/panda/pdfeditor/invoice"
+
+// CreateInvoiceRequest carries the fields of a new Invoice. Number, Date
+// and Status are assigned by the server: CreateInvoice always produces a
+// StatusDraft invoice, SealInvoice is what assigns a final number.
+type CreateInvoiceRequest struct {
+	Seller   invoice.Party         `json:"seller"`
+	Buyer    invoice.Party         `json:"buyer"`
+	Items    []invoice.InvoiceItem `json:"items"`
+	Discount float64               `json:"discount"`
+	Shipping float64               `json:"shipping"`
+	IBAN     string                `json:"iban"`
+	SWIFT    string                `json:"swift"`
+	Currency string                `json:"currency"`
+}
+
+// InvoiceResponse wraps the id and full Invoice state. It is returned by
+// CreateInvoice, GetInvoice and SealInvoice.
+type InvoiceResponse struct {
+	ID      string          `json:"id"`
+	Invoice invoice.Invoice `json:"invoice"`
+}
+
+// GetInvoiceRequest looks an invoice up by the id CreateInvoice returned.
+type GetInvoiceRequest struct {
+	ID string `json:"id"`
+}
+
+// RenderInvoiceRequest renders an invoice to HTML in the given language.
+// Proforma renders it as a draft, watermarked copy with a provisional
+// number and no issue date, instead of the sealed invoice's own. AsPDF
+// additionally renders the invoice through the creator's configured
+// Renderer and returns it in RenderInvoiceResponse.PDF.
+type RenderInvoiceRequest struct {
+	ID       string `json:"id"`
+	Lang     string `json:"lang"`
+	Proforma bool   `json:"proforma"`
+	AsPDF    bool   `json:"as_pdf"`
+}
+
+// RenderInvoiceResponse carries the rendered invoice markup, plus the
+// rendered PDF bytes when the request asked for AsPDF.
+type RenderInvoiceResponse struct {
+	HTML string `json:"html"`
+	PDF  []byte `json:"pdf,omitempty"`
+}
+
+// SealInvoiceRequest commits a draft invoice: the store assigns it the
+// next sequential number from its per-year counter, so the caller has
+// no number to supply.
+type SealInvoiceRequest struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,113 @@
+package invoiceservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/panda/pdfeditor/invoice"
+)
+
+// Server is the invoice gRPC service's implementation. It has no PDF
+// parsing of its own: invoices are either created directly through
+// CreateInvoice, or already persisted by invoice.PDFCreator.RecreatePDF
+// (a future request wires that flow to the same Store).
+type Server struct {
+	store    invoice.Store
+	creators map[string]*invoice.PDFCreator
+}
+
+// NewServer builds a Server backed by store, with one renderer per
+// supported locale. Creators default to NativeRenderer rather than
+// invoice.New's usual WKHTMLRenderer default: WKHTMLRenderer needs
+// wkpdf.Init() called once at process startup, which nothing here does,
+// and calling it without that would panic every in-flight RPC along
+// with it. Callers that do run their own wkpdf.Init()/Destroy() can
+// still opt a creator back into WKHTMLRenderer via WithRenderer.
+func NewServer(store invoice.Store) (*Server, error) {
+	creators := make(map[string]*invoice.PDFCreator)
+	for _, lang := range []string{"en", "de", "pl"} {
+		c, err := invoice.New(nil, lang)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't load %s renderer: %v", lang, err)
+		}
+		c.WithRenderer(invoice.NewNativeRenderer())
+		creators[lang] = c
+	}
+	return &Server{store: store, creators: creators}, nil
+}
+
+func (s *Server) CreateInvoice(ctx context.Context, req *CreateInvoiceRequest) (*InvoiceResponse, error) {
+	inv := invoice.Invoice{
+		Seller:   req.Seller,
+		Buyer:    req.Buyer,
+		Items:    req.Items,
+		Discount: req.Discount,
+		Shipping: req.Shipping,
+		IBAN:     req.IBAN,
+		SWIFT:    req.SWIFT,
+		Currency: req.Currency,
+		Status:   invoice.StatusDraft,
+	}
+	id, err := s.store.CreateInvoice(inv)
+	if err != nil {
+		return nil, err
+	}
+	return &InvoiceResponse{ID: id, Invoice: inv}, nil
+}
+
+func (s *Server) GetInvoice(ctx context.Context, req *GetInvoiceRequest) (*InvoiceResponse, error) {
+	inv, err := s.store.GetInvoice(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &InvoiceResponse{ID: req.ID, Invoice: inv}, nil
+}
+
+func (s *Server) RenderInvoice(ctx context.Context, req *RenderInvoiceRequest) (*RenderInvoiceResponse, error) {
+	inv, err := s.store.GetInvoice(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	lang := req.Lang
+	if lang == "" {
+		lang = "en"
+	}
+	creator, ok := s.creators[lang]
+	if !ok {
+		return nil, fmt.Errorf("unsupported locale %q", lang)
+	}
+	var html []byte
+	if req.Proforma {
+		html, err = creator.RenderProforma(inv)
+	} else {
+		html, err = creator.RenderInvoice(inv)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp := &RenderInvoiceResponse{HTML: string(html)}
+
+	if req.AsPDF {
+		var pdfBytes []byte
+		if req.Proforma {
+			pdfBytes, err = creator.RenderProformaPDF(inv)
+		} else {
+			pdfBytes, err = creator.RenderInvoicePDF(inv)
+		}
+		if err != nil {
+			return nil, err
+		}
+		resp.PDF = pdfBytes
+	}
+
+	return resp, nil
+}
+
+func (s *Server) SealInvoice(ctx context.Context, req *SealInvoiceRequest) (*InvoiceResponse, error) {
+	inv, err := s.store.SealInvoice(req.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &InvoiceResponse{ID: req.ID, Invoice: inv}, nil
+}
@@ -0,0 +1,98 @@
+package invoiceservice
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// InvoiceServer is implemented by Server. It exists so grpc.ServiceDesc
+// can be registered without hand-written protoc-gen-go-grpc stubs.
+type InvoiceServer interface {
+	CreateInvoice(context.Context, *CreateInvoiceRequest) (*InvoiceResponse, error)
+	GetInvoice(context.Context, *GetInvoiceRequest) (*InvoiceResponse, error)
+	RenderInvoice(context.Context, *RenderInvoiceRequest) (*RenderInvoiceResponse, error)
+	SealInvoice(context.Context, *SealInvoiceRequest) (*InvoiceResponse, error)
+}
+
+// ServiceDesc is the grpc.ServiceDesc for the Invoice service. Register
+// it with RegisterInvoiceServer.
+const serviceName = "invoice.Invoice"
+
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*InvoiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "CreateInvoice", Handler: createInvoiceHandler},
+		{MethodName: "GetInvoice", Handler: getInvoiceHandler},
+		{MethodName: "RenderInvoice", Handler: renderInvoiceHandler},
+		{MethodName: "SealInvoice", Handler: sealInvoiceHandler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "invoice/invoiceservice/invoice.proto",
+}
+
+// RegisterInvoiceServer registers srv with s.
+func RegisterInvoiceServer(s grpc.ServiceRegistrar, srv InvoiceServer) {
+	s.RegisterService(&ServiceDesc, srv)
+}
+
+func createInvoiceHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(CreateInvoiceRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoiceServer).CreateInvoice(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: serviceName + "/CreateInvoice"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoiceServer).CreateInvoice(ctx, req.(*CreateInvoiceRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func getInvoiceHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(GetInvoiceRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoiceServer).GetInvoice(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: serviceName + "/GetInvoice"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoiceServer).GetInvoice(ctx, req.(*GetInvoiceRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func renderInvoiceHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(RenderInvoiceRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoiceServer).RenderInvoice(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: serviceName + "/RenderInvoice"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoiceServer).RenderInvoice(ctx, req.(*RenderInvoiceRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
+
+func sealInvoiceHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(SealInvoiceRequest)
+	if err := dec(req); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InvoiceServer).SealInvoice(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: serviceName + "/SealInvoice"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InvoiceServer).SealInvoice(ctx, req.(*SealInvoiceRequest))
+	}
+	return interceptor(ctx, req, info, handler)
+}
@@ -0,0 +1,138 @@
+package invoice
+
+import "fmt"
+
+// Locale holds the translated strings used to parse and re-render an
+// invoice PDF in a given language. Company data (name, address, tax IDs,
+// bank details) is not part of a Locale: it never changes between
+// languages, only the labels around it do.
+type Locale struct {
+	Code string
+
+	InvoiceNumberNoticeLine1 string
+	InvoiceNumberNoticeLine2 string
+
+	BillTo       string
+	ShipTo       string
+	Description  string
+	Qty          string
+	UnitPrice    string
+	LineTotal    string
+	Subtotal     string
+	Discount     string
+	Shipping     string
+	FreeShipping string
+
+	PaymentDetails     string
+	PaymentTerms       string
+	BankLabel          string
+	AccountHolderLabel string
+
+	Status map[string]string
+}
+
+var locales = map[string]Locale{
+	"en": {
+		Code:                     "en",
+		InvoiceNumberNoticeLine1: "INVOICE NUMBER MUST BE INCLUDED WITH YOUR BANK PAYMENT OTHERWISE DELAYS",
+		InvoiceNumberNoticeLine2: "MAY OCCUR",
+		BillTo:                   "Bill to:",
+		ShipTo:                   "Ship to:",
+		Description:              "Description",
+		Qty:                      "Qty",
+		UnitPrice:                "Unit Price",
+		LineTotal:                "Line Total",
+		Subtotal:                 "Subtotal:",
+		Discount:                 "Discount:",
+		Shipping:                 "Shipping:",
+		FreeShipping:             "Free shipping",
+		PaymentDetails:           "Payment details:",
+		PaymentTerms:             "Payment must be made within 30 days from issue date.",
+		BankLabel:                "BANK:",
+		AccountHolderLabel:       "ACCOUNT HOLDER:",
+		Status:                   map[string]string{"PAID": "PAID"},
+	},
+	"de": {
+		Code:                     "de",
+		InvoiceNumberNoticeLine1: "DIE RECHNUNGSNUMMER MUSS BEI IHRER ÜBERWEISUNG ANGEGEBEN WERDEN, SONST",
+		InvoiceNumberNoticeLine2: "KANN ES ZU VERZÖGERUNGEN KOMMEN",
+		BillTo:                   "Rechnungsadresse:",
+		ShipTo:                   "Lieferadresse:",
+		Description:              "Beschreibung",
+		Qty:                      "Menge",
+		UnitPrice:                "Einzelpreis",
+		LineTotal:                "Gesamtpreis",
+		Subtotal:                 "Zwischensumme:",
+		Discount:                 "Rabatt:",
+		Shipping:                 "Versand:",
+		FreeShipping:             "Kostenloser Versand",
+		PaymentDetails:           "Zahlungsdetails:",
+		PaymentTerms:             "Die Zahlung ist innerhalb von 30 Tagen ab Rechnungsdatum fällig.",
+		BankLabel:                "BANK:",
+		AccountHolderLabel:       "KONTOINHABER:",
+		Status:                   map[string]string{"PAID": "BEZAHLT"},
+	},
+	"pl": {
+		Code:                     "pl",
+		InvoiceNumberNoticeLine1: "NUMER FAKTURY MUSI BYĆ PODANY W TYTULE PRZELEWU, W PRZECIWNYM RAZIE",
+		InvoiceNumberNoticeLine2: "PŁATNOŚĆ MOŻE ULEC OPÓŹNIENIU",
+		BillTo:                   "Nabywca:",
+		ShipTo:                   "Odbiorca:",
+		Description:              "Opis",
+		Qty:                      "Ilość",
+		UnitPrice:                "Cena jednostkowa",
+		LineTotal:                "Suma wiersza",
+		Subtotal:                 "Suma częściowa:",
+		Discount:                 "Rabat:",
+		Shipping:                 "Wysyłka:",
+		FreeShipping:             "Bezpłatna wysyłka",
+		PaymentDetails:           "Szczegóły płatności:",
+		PaymentTerms:             "Płatność musi zostać dokonana w ciągu 30 dni od daty wystawienia.",
+		BankLabel:                "BANK:",
+		AccountHolderLabel:       "WŁAŚCICIEL KONTA:",
+		Status:                   map[string]string{"PAID": "ZAPŁACONO"},
+	},
+}
+
+// getLocale looks up a registered Locale by language code (e.g. "en",
+// "de", "pl"). It fails closed: an unsupported code is an error rather
+// than a silent fallback to English, since the caller also needs a
+// matching invoice.<lang>.pdf-html.tmpl template.
+func getLocale(lang string) (Locale, error) {
+	l, ok := locales[lang]
+	if !ok {
+		return Locale{}, fmt.Errorf("unsupported locale %q", lang)
+	}
+	return l, nil
+}
+
+// pdfHeadFor returns the fixed MatchX GmbH header lines with the
+// language-specific invoice-number notice spliced in.
+func pdfHeadFor(l Locale) []string {
+	return []string{
+		"MatchX GmbH",
+		"Brückenstraße 4",
+		"10179 Berlin",
+		"Germany",
+		"Tax number: 37/436/50071",
+		"awesome@matchx.io",
+		"VAT ID: DE309834893",
+		l.InvoiceNumberNoticeLine1,
+		l.InvoiceNumberNoticeLine2,
+		"1 of 1",
+	}
+}
+
+// pdfPaymentInfoFor returns the fixed MatchX GmbH bank details with the
+// language-specific labels spliced in.
+func pdfPaymentInfoFor(l Locale) []string {
+	return []string{
+		l.PaymentDetails,
+		l.PaymentTerms,
+		"BIC: PBNKDEFF",
+		"IBAN: DE12 1001 0010 0685 1601 27",
+		fmt.Sprintf("%s Post Bank", l.BankLabel),
+		fmt.Sprintf("%s MatchX GmbH", l.AccountHolderLabel),
+		"PayPal: info@matchx.io",
+	}
+}
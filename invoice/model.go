@@ -0,0 +1,70 @@
+package invoice
+
+import "time"
+
+// InvoiceStatus is the lifecycle state of an Invoice.
+type InvoiceStatus string
+
+const (
+	StatusDraft  InvoiceStatus = "DRAFT"
+	StatusIssued InvoiceStatus = "ISSUED"
+	StatusPaid   InvoiceStatus = "PAID"
+)
+
+// Party is a seller or buyer on an Invoice.
+type Party struct {
+	Name    string
+	Address []string
+	Country string
+	VATID   string
+}
+
+// InvoiceItem is a single line of an Invoice. VATRate overrides the rate
+// this line is taxed at (e.g. a reduced rate on a book next to
+// standard-rate hardware); zero means "use the invoice's computed
+// rate". renderInvoiceParams fills it in with the rate actually used,
+// even when it wasn't set explicitly, so callers can read back what was
+// charged.
+type InvoiceItem struct {
+	Title     string
+	Qty       float64
+	UnitPrice float64
+	LineTotal float64
+	VATRate   float64
+}
+
+// Invoice is the structured, template- and language-independent
+// representation of an invoice. PDFCreator builds one by parsing a
+// source PDF; invoiceservice.Server lets callers create one directly,
+// without a source PDF at all.
+type Invoice struct {
+	Number   string
+	Date     time.Time
+	DueDate  time.Time
+	Seller   Party
+	Buyer    Party
+	Items    []InvoiceItem
+	Discount float64
+	Shipping float64
+
+	// ShippingVATRate overrides the rate shipping is taxed at, for
+	// invoices that mix a reduced shipping rate with a standard rate on
+	// the items themselves. Zero means "use the invoice's computed
+	// rate", same as InvoiceItem.VATRate.
+	ShippingVATRate float64
+
+	IBAN     string
+	SWIFT    string
+	Currency string
+	Status   InvoiceStatus
+}
+
+// Subtotal is the sum of all line totals (Qty * UnitPrice) before
+// discount, shipping and VAT.
+func (inv Invoice) Subtotal() float64 {
+	var total float64
+	for _, item := range inv.Items {
+		total += item.Qty * item.UnitPrice
+	}
+	return total
+}
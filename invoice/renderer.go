@@ -0,0 +1,10 @@
+package invoice
+
+// Renderer turns a rendered invoice into final PDF bytes. WKHTMLRenderer
+// converts the HTML regenerateInvoicePDF already produced; NativeRenderer
+// ignores that HTML and draws the PDF straight from the Invoice struct,
+// so it keeps working in environments (Alpine containers, FaaS) where
+// wkhtmltopdf's C library isn't installed.
+type Renderer interface {
+	Render(html []byte, inv Invoice) ([]byte, error)
+}
@@ -0,0 +1,124 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/leekchan/accounting"
+)
+
+// NativeRenderer draws the invoice straight from the Invoice struct using
+// a pure-Go PDF library, so the module keeps working in environments
+// (Alpine containers, FaaS) where wkhtmltopdf's C library isn't
+// available. It ignores the pre-rendered HTML entirely.
+type NativeRenderer struct{}
+
+// NewNativeRenderer returns a NativeRenderer.
+func NewNativeRenderer() *NativeRenderer {
+	return &NativeRenderer{}
+}
+
+func (r *NativeRenderer) Render(_ []byte, inv Invoice) ([]byte, error) {
+	ac := accounting.Accounting{Symbol: inv.Currency + " ", Precision: 2}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 14)
+	pdf.Cell(0, 8, inv.Seller.Name)
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "", 10)
+	for _, line := range inv.Seller.Address {
+		pdf.Cell(0, 5, line)
+		pdf.Ln(5)
+	}
+	pdf.Cell(0, 5, "VAT ID: "+inv.Seller.VATID)
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "B", 12)
+	title := "Invoice"
+	if inv.Status != "" {
+		title = fmt.Sprintf("Invoice %s %s", inv.Number, inv.Status)
+	}
+	pdf.Cell(0, 8, title)
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "B", 10)
+	pdf.Cell(0, 5, "Bill to:")
+	pdf.Ln(5)
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(0, 5, inv.Buyer.Name)
+	pdf.Ln(5)
+	for _, line := range inv.Buyer.Address {
+		pdf.Cell(0, 5, line)
+		pdf.Ln(5)
+	}
+	if inv.Buyer.VATID != "" {
+		pdf.Cell(0, 5, "VAT Number: "+inv.Buyer.VATID)
+		pdf.Ln(5)
+	}
+	pdf.Ln(5)
+
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(120, 6, "Description", "B", 0, "L", false, 0, "")
+	pdf.CellFormat(20, 6, "Qty", "B", 0, "R", false, 0, "")
+	pdf.CellFormat(25, 6, "Price", "B", 0, "R", false, 0, "")
+	pdf.CellFormat(25, 6, "Total", "B", 1, "R", false, 0, "")
+
+	pdf.SetFont("Arial", "", 10)
+	for _, item := range inv.Items {
+		pdf.CellFormat(120, 6, item.Title, "", 0, "L", false, 0, "")
+		pdf.CellFormat(20, 6, fmt.Sprintf("%.2f", item.Qty), "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 6, ac.FormatMoney(item.UnitPrice), "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 6, ac.FormatMoney(item.Qty*item.UnitPrice), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(5)
+
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(165, 6, "Subtotal:", "", 0, "R", false, 0, "")
+	pdf.CellFormat(25, 6, ac.FormatMoney(inv.Subtotal()), "", 1, "R", false, 0, "")
+	if inv.Discount > 0 {
+		pdf.CellFormat(165, 6, "Discount:", "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 6, "-"+ac.FormatMoney(inv.Discount), "", 1, "R", false, 0, "")
+	}
+	if inv.Shipping > 0 {
+		pdf.CellFormat(165, 6, "Shipping:", "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 6, ac.FormatMoney(inv.Shipping), "", 1, "R", false, 0, "")
+	}
+
+	// VAT, like in renderInvoiceParams, is summed per line so mixed
+	// rates (e.g. a reduced-rate line next to a standard-rate one) and
+	// reverse charge add up correctly; by the time Render runs, each
+	// item's VATRate has already been set to the rate actually charged.
+	var vatTotal float64
+	for _, item := range inv.Items {
+		vatTotal += item.Qty * item.UnitPrice * item.VATRate
+	}
+	vatTotal += inv.Shipping * inv.ShippingVATRate
+	totalExclVAT := inv.Subtotal() + inv.Shipping - inv.Discount
+	total := totalExclVAT + vatTotal
+
+	pdf.CellFormat(165, 6, "VAT:", "", 0, "R", false, 0, "")
+	pdf.CellFormat(25, 6, ac.FormatMoney(vatTotal), "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(165, 6, "Total:", "", 0, "R", false, 0, "")
+	pdf.CellFormat(25, 6, ac.FormatMoney(total), "", 1, "R", false, 0, "")
+
+	pdf.Ln(5)
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(0, 5, "Payment details:")
+	pdf.Ln(5)
+	pdf.Cell(0, 5, "IBAN: "+inv.IBAN)
+	pdf.Ln(5)
+	pdf.Cell(0, 5, "SWIFT: "+inv.SWIFT)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
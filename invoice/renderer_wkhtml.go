@@ -0,0 +1,79 @@
+//go:build cgo
+
+package invoice
+
+import (
+	"bytes"
+
+	wkpdf "github.com/adrg/go-wkhtmltopdf"
+)
+
+// defaultRenderer is WKHTMLRenderer on cgo-enabled builds, matching the
+// module's historical default; see renderer_wkhtml_stub.go for the
+// NativeRenderer fallback used when cgo (and wkhtmltopdf's C library)
+// isn't available.
+func defaultRenderer() Renderer {
+	return NewWKHTMLRenderer()
+}
+
+// WKHTMLRenderer converts the already-rendered invoice HTML to PDF using
+// wkhtmltopdf's C library. Callers must have called wkpdf.Init() (and
+// defer wkpdf.Destroy()) once at process startup, as main.go does.
+type WKHTMLRenderer struct {
+	PaperSize    wkpdf.PaperSize
+	Orientation  wkpdf.Orientation
+	MarginTop    uint
+	MarginBottom uint
+	MarginLeft   uint
+	MarginRight  uint
+	HeaderText   string
+	FooterText   string
+}
+
+// NewWKHTMLRenderer returns a WKHTMLRenderer configured with the page
+// size, margins and header/footer this module has always used.
+func NewWKHTMLRenderer() *WKHTMLRenderer {
+	return &WKHTMLRenderer{
+		PaperSize:    wkpdf.A4,
+		Orientation:  wkpdf.Portrait,
+		MarginTop:    10,
+		MarginBottom: 10,
+		MarginLeft:   10,
+		MarginRight:  10,
+	}
+}
+
+func (r *WKHTMLRenderer) Render(html []byte, inv Invoice) ([]byte, error) {
+	converter, err := wkpdf.NewConverter()
+	if err != nil {
+		return nil, err
+	}
+
+	converter.PaperSize = r.PaperSize
+	converter.Orientation = r.Orientation
+	converter.MarginTop = r.MarginTop
+	converter.MarginBottom = r.MarginBottom
+	converter.MarginLeft = r.MarginLeft
+	converter.MarginRight = r.MarginRight
+
+	object, err := wkpdf.NewObjectFromReader(bytes.NewReader(html))
+	if err != nil {
+		return nil, err
+	}
+
+	if r.HeaderText != "" {
+		object.Header.ContentCenter = r.HeaderText
+	}
+	if r.FooterText != "" {
+		object.Footer.ContentCenter = r.FooterText
+	}
+
+	converter.Add(object)
+
+	var out bytes.Buffer
+	if err := converter.Run(&out); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package invoice
+
+// defaultRenderer is NativeRenderer on builds without cgo, since
+// WKHTMLRenderer (renderer_wkhtml.go) cgo-imports wkhtmltopdf's C
+// library and isn't compiled in on this build. Callers that need
+// WKHTMLRenderer specifically must build with cgo enabled and call
+// WithRenderer(NewWKHTMLRenderer()) themselves.
+func defaultRenderer() Renderer {
+	return NewNativeRenderer()
+}
@@ -0,0 +1,171 @@
+package invoice
+
+import (
+	"fmt"
+	"strings"
+)
+
+// vat holds a country's VAT rates. rateUnderLimit is the rate charged on
+// a domestic sale (seller and buyer in the same country); rateOverLimit
+// is the country's own standard rate, charged on cross-border B2C sales
+// once the seller is over the EU-wide OSS distance-selling threshold
+// (in practice: almost always, since the threshold is a combined
+// EUR 10,000/year). The two only differ for a German seller selling
+// into another member state; for a seller based in that state itself,
+// both are the same number.
+type vat struct {
+	rateUnderLimit float64
+	rateOverLimit  float64
+}
+
+var vatMap = map[string]*vat{
+	"Germany":        {rateUnderLimit: 0.19, rateOverLimit: 0.19},
+	"Netherlands":    {rateUnderLimit: 0.21, rateOverLimit: 0.21},
+	"Austria":        {rateUnderLimit: 0.20, rateOverLimit: 0.20},
+	"Belgium":        {rateUnderLimit: 0.21, rateOverLimit: 0.21},
+	"Bulgaria":       {rateUnderLimit: 0.20, rateOverLimit: 0.20},
+	"Croatia":        {rateUnderLimit: 0.25, rateOverLimit: 0.25},
+	"Cyprus":         {rateUnderLimit: 0.19, rateOverLimit: 0.19},
+	"Czech Republic": {rateUnderLimit: 0.21, rateOverLimit: 0.21},
+	"Denmark":        {rateUnderLimit: 0.25, rateOverLimit: 0.25},
+	"Estonia":        {rateUnderLimit: 0.20, rateOverLimit: 0.20},
+	"Finland":        {rateUnderLimit: 0.24, rateOverLimit: 0.24},
+	"France":         {rateUnderLimit: 0.20, rateOverLimit: 0.20},
+	"Greece":         {rateUnderLimit: 0.24, rateOverLimit: 0.24},
+	"Hungary":        {rateUnderLimit: 0.27, rateOverLimit: 0.27},
+	"Ireland":        {rateUnderLimit: 0.23, rateOverLimit: 0.23},
+	"Italy":          {rateUnderLimit: 0.22, rateOverLimit: 0.22},
+	"Latvia":         {rateUnderLimit: 0.21, rateOverLimit: 0.21},
+	"Lithuania":      {rateUnderLimit: 0.21, rateOverLimit: 0.21},
+	"Luxembourg":     {rateUnderLimit: 0.17, rateOverLimit: 0.17},
+	"Malta":          {rateUnderLimit: 0.18, rateOverLimit: 0.18},
+	"Monaco":         {rateUnderLimit: 0.20, rateOverLimit: 0.20},
+	"Poland":         {rateUnderLimit: 0.23, rateOverLimit: 0.23},
+	"Portugal":       {rateUnderLimit: 0.23, rateOverLimit: 0.23},
+	"Romania":        {rateUnderLimit: 0.19, rateOverLimit: 0.19},
+	"Slovakia":       {rateUnderLimit: 0.20, rateOverLimit: 0.20},
+	"Slovenia":       {rateUnderLimit: 0.22, rateOverLimit: 0.22},
+	"Spain":          {rateUnderLimit: 0.21, rateOverLimit: 0.21},
+	"Sweden":         {rateUnderLimit: 0.25, rateOverLimit: 0.25},
+	"UK":             {rateUnderLimit: 0.20, rateOverLimit: 0.20},
+}
+
+var vatCountryCode = map[string]string{
+	"DE": "Germany",
+	"NL": "Netherlands",
+	"AT": "Austria",
+	"BE": "Belgium",
+	"BG": "Bulgaria",
+	"HR": "Croatia",
+	"CY": "Cyprus",
+	"CZ": "Czech Republic",
+	"DK": "Denmark",
+	"EE": "Estonia",
+	"FI": "Finland",
+	"FR": "France",
+	"EL": "Greece",
+	"HU": "Hungary",
+	"IE": "Ireland",
+	"IT": "Italy",
+	"LV": "Latvia",
+	"LT": "Lithuania",
+	"LU": "Luxembourg",
+	"MT": "Malta",
+	"PL": "Poland",
+	"PT": "Portugal",
+	"RO": "Romania",
+	"SK": "Slovakia",
+	"SI": "Slovenia",
+	"ES": "Spain",
+	"SE": "Sweden",
+	"GB": "UK",
+	"XI": "UK",
+}
+
+func getVATRate(country string) *vat {
+	if strings.Contains(country, "VAT Number:") {
+		country = vatCountryCode[country[12:14]]
+	}
+	for k, v := range vatMap {
+		if strings.Contains(country, k) {
+			return v
+		}
+	}
+	return nil
+}
+
+// computeVATRate works out the VAT rate and whether reverse charge
+// applies for the invoice being rendered. EU B2B sales where the buyer
+// is in a different member state than the seller and has a VAT
+// identification number are zero-rated; the recipient accounts for VAT
+// themselves under Art. 196 of Directive 2006/112/EC. Everything else
+// (domestic sales, and B2C cross-border sales) is charged at the
+// buyer's country's standard rate.
+func (p *pdf) computeVATRate() (rate float64, reverseCharge bool, err error) {
+	seller, buyer := p.invoice.Seller, p.invoice.Buyer
+	vatRate := getVATRate(buyer.Country)
+	if vatRate == nil {
+		return 0, false, fmt.Errorf("no vat rate found for billing address: %s", strings.Join(buyer.Address, "\n"))
+	}
+	if strings.EqualFold(buyer.Country, seller.Country) {
+		return vatRate.rateUnderLimit, false, nil
+	}
+	if buyer.VATID == "" {
+		return vatRate.rateOverLimit, false, nil
+	}
+	if !p.buyerVATIDValid(buyer.VATID) {
+		return vatRate.rateOverLimit, false, nil
+	}
+	return 0, true, nil
+}
+
+// applyVATRates works out computeVATRate and fills in the rate actually
+// charged on each item and on shipping: a line's own VATRate/
+// ShippingVATRate, if set, wins over the invoice-wide rate, and reverse
+// charge always wins over both. It mutates p.invoice in place and
+// returns the invoice-wide base rate and whether reverse charge applies,
+// so callers can still render things like the VAT percentage label or a
+// reverse charge note.
+func (p *pdf) applyVATRates() (rate float64, reverseCharge bool, err error) {
+	rate, reverseCharge, err = p.computeVATRate()
+	if err != nil {
+		return 0, false, err
+	}
+	for i, item := range p.invoice.Items {
+		lineRate := rate
+		if item.VATRate != 0 {
+			lineRate = item.VATRate
+		}
+		if reverseCharge {
+			lineRate = 0
+		}
+		p.invoice.Items[i].VATRate = lineRate
+	}
+	shippingRate := rate
+	if p.invoice.ShippingVATRate != 0 {
+		shippingRate = p.invoice.ShippingVATRate
+	}
+	if reverseCharge {
+		shippingRate = 0
+	}
+	p.invoice.ShippingVATRate = shippingRate
+	return rate, reverseCharge, nil
+}
+
+// buyerVATIDValid checks buyer.VATID against VIES, when a VIESClient is
+// configured. Without one, any non-empty VAT ID of the expected shape is
+// accepted at face value.
+func (p *pdf) buyerVATIDValid(vatID string) bool {
+	if len(vatID) < 2 {
+		return false
+	}
+	if p.viesClient == nil {
+		return len(vatID) > 2
+	}
+	countryCode, number := vatID[:2], vatID[2:]
+	valid, err := p.viesClient.CheckVAT(countryCode, number)
+	if err != nil {
+		return p.viesClient.FailOpen
+	}
+	return valid
+}
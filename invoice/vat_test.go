@@ -0,0 +1,127 @@
+package invoice
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func viesServer(t *testing.T, valid bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/">
+  <soapenv:Body>
+    <checkVatResponse><valid>%t</valid></checkVatResponse>
+  </soapenv:Body>
+</soapenv:Envelope>`, valid)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestComputeVATRate(t *testing.T) {
+	domestic := pdf{invoice: Invoice{
+		Seller: Party{Country: "Germany"},
+		Buyer:  Party{Country: "Germany"},
+	}}
+	rate, reverseCharge, err := domestic.computeVATRate()
+	if err != nil {
+		t.Fatalf("domestic: unexpected error: %v", err)
+	}
+	if reverseCharge {
+		t.Fatalf("domestic: reverse charge should not apply")
+	}
+	if rate != 0.19 {
+		t.Fatalf("domestic: got rate %v, want 0.19", rate)
+	}
+
+	crossBorderB2C := pdf{invoice: Invoice{
+		Seller: Party{Country: "Germany"},
+		Buyer:  Party{Country: "Netherlands"},
+	}}
+	rate, reverseCharge, err = crossBorderB2C.computeVATRate()
+	if err != nil {
+		t.Fatalf("cross-border B2C: unexpected error: %v", err)
+	}
+	if reverseCharge {
+		t.Fatalf("cross-border B2C: reverse charge should not apply without a buyer VAT ID")
+	}
+	if rate != 0.21 {
+		t.Fatalf("cross-border B2C: got rate %v, want 0.21", rate)
+	}
+
+	crossBorderB2B := pdf{invoice: Invoice{
+		Seller: Party{Country: "Germany"},
+		Buyer:  Party{Country: "Netherlands", VATID: "NL809977163B01"},
+	}}
+	_, reverseCharge, err = crossBorderB2B.computeVATRate()
+	if err != nil {
+		t.Fatalf("cross-border B2B: unexpected error: %v", err)
+	}
+	if !reverseCharge {
+		t.Fatalf("cross-border B2B: reverse charge should apply for a VAT-registered buyer")
+	}
+
+	unknownCountry := pdf{invoice: Invoice{
+		Seller: Party{Country: "Germany"},
+		Buyer:  Party{Country: "Atlantis"},
+	}}
+	if _, _, err := unknownCountry.computeVATRate(); err == nil {
+		t.Fatalf("unknown country: expected an error, got none")
+	}
+}
+
+func TestComputeVATRateWithVIES(t *testing.T) {
+	validSrv := viesServer(t, true)
+	p := pdf{
+		invoice: Invoice{
+			Seller: Party{Country: "Germany"},
+			Buyer:  Party{Country: "Netherlands", VATID: "NL809977163B01"},
+		},
+		viesClient: &VIESClient{
+			Endpoint:   validSrv.URL,
+			HTTPClient: validSrv.Client(),
+			cache:      make(map[string]viesCacheEntry),
+		},
+	}
+	_, reverseCharge, err := p.computeVATRate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reverseCharge {
+		t.Fatalf("expected reverse charge for a VIES-valid VAT ID")
+	}
+
+	invalidSrv := viesServer(t, false)
+	p = pdf{
+		invoice: Invoice{
+			Seller: Party{Country: "Germany"},
+			Buyer:  Party{Country: "Netherlands", VATID: "NL809977163B01"},
+		},
+		viesClient: &VIESClient{
+			Endpoint:   invalidSrv.URL,
+			HTTPClient: invalidSrv.Client(),
+			cache:      make(map[string]viesCacheEntry),
+		},
+	}
+	rate, reverseCharge, err := p.computeVATRate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reverseCharge {
+		t.Fatalf("expected no reverse charge for a VIES-invalid VAT ID")
+	}
+	if rate != 0.21 {
+		t.Fatalf("got rate %v, want 0.21 (standard Netherlands rate)", rate)
+	}
+}
+
+func TestBuyerVATIDValidShortID(t *testing.T) {
+	p := pdf{}
+	for _, vatID := range []string{"", "N"} {
+		if p.buyerVATIDValid(vatID) {
+			t.Errorf("buyerVATIDValid(%q) = true, want false", vatID)
+		}
+	}
+}
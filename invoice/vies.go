@@ -0,0 +1,101 @@
+package invoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultVIESEndpoint = "https://ec.europa.eu/taxation_customs/vies/services/checkVatService"
+
+// VIESClient validates EU VAT identification numbers against the European
+// Commission's VIES checkVat SOAP service. Results are cached for TTL,
+// since VIES is slow and rate-limited and the same buyer is usually
+// billed repeatedly.
+type VIESClient struct {
+	Endpoint   string
+	HTTPClient *http.Client
+	TTL        time.Duration
+	// FailOpen controls what happens when VIES itself is unreachable or
+	// errors: true treats the VAT ID as valid (reverse charge still
+	// applies), false treats it as invalid (falls back to charging VAT).
+	FailOpen bool
+
+	mu    sync.Mutex
+	cache map[string]viesCacheEntry
+}
+
+type viesCacheEntry struct {
+	valid     bool
+	expiresAt time.Time
+}
+
+// NewVIESClient returns a VIESClient pointed at the production VIES
+// endpoint with a 24h result cache.
+func NewVIESClient(failOpen bool) *VIESClient {
+	return &VIESClient{
+		Endpoint:   defaultVIESEndpoint,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		TTL:        24 * time.Hour,
+		FailOpen:   failOpen,
+		cache:      make(map[string]viesCacheEntry),
+	}
+}
+
+// CheckVAT validates a VAT identification number split into its member
+// state country code and the remaining digits, e.g. CheckVAT("NL",
+// "809977163B01").
+func (c *VIESClient) CheckVAT(countryCode, number string) (bool, error) {
+	key := countryCode + number
+
+	c.mu.Lock()
+	if entry, ok := c.cache[key]; ok && time.Now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		return entry.valid, nil
+	}
+	c.mu.Unlock()
+
+	valid, err := c.checkVat(countryCode, number)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	c.cache[key] = viesCacheEntry{valid: valid, expiresAt: time.Now().Add(c.TTL)}
+	c.mu.Unlock()
+	return valid, nil
+}
+
+type checkVatEnvelope struct {
+	Body struct {
+		CheckVatResponse struct {
+			Valid bool `xml:"valid"`
+		} `xml:"checkVatResponse"`
+	} `xml:"Body"`
+}
+
+func (c *VIESClient) checkVat(countryCode, number string) (bool, error) {
+	body := fmt.Sprintf(`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:urn="urn:ec.europa.eu:taxud:vies:services:checkVat:types">
+  <soapenv:Body>
+    <urn:checkVat>
+      <urn:countryCode>%s</urn:countryCode>
+      <urn:vatNumber>%s</urn:vatNumber>
+    </urn:checkVat>
+  </soapenv:Body>
+</soapenv:Envelope>`, countryCode, number)
+
+	resp, err := c.HTTPClient.Post(c.Endpoint, "text/xml", bytes.NewBufferString(body))
+	if err != nil {
+		return false, fmt.Errorf("couldn't reach VIES: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var env checkVatEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return false, fmt.Errorf("couldn't parse VIES response: %v", err)
+	}
+	return env.Body.CheckVatResponse.Valid, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
@@ -13,13 +14,16 @@ import (
 )
 
 func main() {
+	lang := flag.String("lang", "en", "language of the source invoices and the re-rendered template (en, de, pl)")
+	flag.Parse()
+
 	if err := wrpdf.Init(); err != nil {
 		logrus.Error(err)
 		os.Exit(1)
 	}
 	defer wrpdf.Destroy()
 	// get rows of content
-	pdfCreator, err := invoice.New(ReadPdfInRow)
+	pdfCreator, err := invoice.New(ReadPdfInRow, *lang)
 	if err != nil {
 		logrus.Error(err)
 		os.Exit(1)